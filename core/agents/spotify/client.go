@@ -0,0 +1,211 @@
+// Package spotify implements a minimal client for the Spotify Web API,
+// used to enrich imported tracks with Spotify-derived metadata
+// (audio features, popularity, genres).
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	apiBaseURL   = "https://api.spotify.com/v1"
+	authTokenURL = "https://accounts.spotify.com/api/token"
+
+	// audioFeaturesBatchSize is the maximum number of track IDs accepted by the
+	// /audio-features endpoint in a single request.
+	audioFeaturesBatchSize = 100
+)
+
+// Client is a thin wrapper around the Spotify Web API, handling
+// client-credentials authentication and the few endpoints needed for
+// metadata enrichment.
+type Client struct {
+	id     string
+	secret string
+	hc     *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewClient creates a Spotify API client that authenticates with the given
+// client ID/secret using the client-credentials OAuth flow.
+func NewClient(id, secret string, hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{id: id, secret: secret, hc: hc}
+}
+
+// Track is a subset of the Spotify track object returned by the search
+// endpoint.
+type Track struct {
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Popularity int            `json:"popularity"`
+	Artists    []simpleArtist `json:"artists"`
+}
+
+// simpleArtist is the truncated artist object embedded in search results,
+// just enough to chain into the /artists endpoint for genres.
+type simpleArtist struct {
+	ID string `json:"id"`
+}
+
+// ArtistID returns the Spotify ID of the track's primary artist, or "" if
+// the track has no artist (which shouldn't normally happen).
+func (t Track) ArtistID() string {
+	if len(t.Artists) == 0 {
+		return ""
+	}
+	return t.Artists[0].ID
+}
+
+// AudioFeatures holds the subset of Spotify's audio-features response that
+// is relevant for metadata enrichment.
+type AudioFeatures struct {
+	ID            string  `json:"id"`
+	Danceability  float64 `json:"danceability"`
+	Energy        float64 `json:"energy"`
+	Tempo         float64 `json:"tempo"`
+	Valence       float64 `json:"valence"`
+	Acousticness  float64 `json:"acousticness"`
+	Loudness      float64 `json:"loudness"`
+	Key           int     `json:"key"`
+	Mode          int     `json:"mode"`
+	TimeSignature int     `json:"time_signature"`
+}
+
+// token requests (and caches) a bearer token via the client-credentials grant.
+func (c *Client) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && time.Now().Before(c.expiresAt) {
+		return c.accessToken, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, authTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.id, c.secret)
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("spotify: requesting token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("spotify: token request failed with status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("spotify: decoding token response: %w", err)
+	}
+
+	c.accessToken = body.AccessToken
+	c.expiresAt = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	return c.accessToken, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, out any) error {
+	token, err := c.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	u := apiBaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("spotify: calling %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("spotify: %s returned status %d", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// SearchTrack looks up a track by ISRC when one is given, otherwise by artist
+// and title, returning the best match or an empty Track if nothing was found.
+func (c *Client) SearchTrack(ctx context.Context, artist, title, isrc string) (Track, error) {
+	q := fmt.Sprintf("track:%s artist:%s", title, artist)
+	if isrc != "" {
+		q = fmt.Sprintf("isrc:%s", isrc)
+	}
+
+	var result struct {
+		Tracks struct {
+			Items []Track `json:"items"`
+		} `json:"tracks"`
+	}
+	query := url.Values{"type": {"track"}, "q": {q}, "limit": {"1"}}
+	if err := c.get(ctx, "/search", query, &result); err != nil {
+		return Track{}, err
+	}
+	if len(result.Tracks.Items) == 0 {
+		return Track{}, nil
+	}
+	return result.Tracks.Items[0], nil
+}
+
+// Genres fetches the genres Spotify associates with an artist. Tracks don't
+// carry genre information themselves; it's only available on the artist
+// object, hence the separate call.
+func (c *Client) Genres(ctx context.Context, artistID string) ([]string, error) {
+	if artistID == "" {
+		return nil, nil
+	}
+	var result struct {
+		Genres []string `json:"genres"`
+	}
+	if err := c.get(ctx, "/artists/"+artistID, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Genres, nil
+}
+
+// AudioFeatures fetches audio features for up to audioFeaturesBatchSize track
+// IDs in a single request. Callers with more IDs must split them into batches.
+func (c *Client) AudioFeatures(ctx context.Context, ids []string) ([]AudioFeatures, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if len(ids) > audioFeaturesBatchSize {
+		return nil, fmt.Errorf("spotify: audio-features accepts at most %d ids, got %d", audioFeaturesBatchSize, len(ids))
+	}
+
+	var result struct {
+		AudioFeatures []AudioFeatures `json:"audio_features"`
+	}
+	query := url.Values{"ids": {strings.Join(ids, ",")}}
+	if err := c.get(ctx, "/audio-features", query, &result); err != nil {
+		return nil, err
+	}
+	return result.AudioFeatures, nil
+}
@@ -21,6 +21,10 @@ import (
 
 type Insights interface {
 	Collect(ctx context.Context) string
+	// RefreshLibraryCounts updates the library counters immediately, bypassing
+	// the rate-limited cadence used by Collect. Called by the scanner after a
+	// scan finishes so Prometheus metrics don't lag behind the library.
+	RefreshLibraryCounts(ctx context.Context)
 }
 
 var (
@@ -29,7 +33,11 @@ var (
 )
 
 type insightsCollector struct {
-	ds model.DataStore
+	ds         model.DataStore
+	prometheus *prometheusMetrics
+
+	mu      sync.Mutex
+	library insights.Data
 }
 
 func NewInsights(ds model.DataStore) Insights {
@@ -43,7 +51,11 @@ func NewInsights(ds model.DataStore) Insights {
 		}
 	}
 	insightsID = id
-	return &insightsCollector{ds: ds}
+	c := &insightsCollector{ds: ds}
+	if conf.Server.Prometheus.Enabled {
+		c.prometheus = promMetrics()
+	}
+	return c
 }
 
 func buildInfo() (map[string]string, string) {
@@ -138,20 +150,57 @@ var staticData = sync.OnceValue(func() insights.Data {
 	return data
 })
 
-func (s insightsCollector) Collect(ctx context.Context) string {
+// RefreshLibraryCounts recomputes the library counters unconditionally,
+// bypassing the 10-minute libraryUpdate cadence. The scan and activity
+// pipelines call this right after a scan completes, so Prometheus gauges
+// (and the next Collect call) reflect the library promptly.
+func (s *insightsCollector) RefreshLibraryCounts(ctx context.Context) {
+	s.mu.Lock()
+	s.library = s.queryLibraryCounts(ctx)
+	s.mu.Unlock()
+	if s.prometheus != nil {
+		s.prometheus.update(s.snapshot(ctx))
+	}
+}
+
+func (s *insightsCollector) queryLibraryCounts(ctx context.Context) insights.Data {
+	var data insights.Data
+	data.Library.Tracks, _ = s.ds.MediaFile(ctx).CountAll()
+	data.Library.Albums, _ = s.ds.Album(ctx).CountAll()
+	data.Library.Artists, _ = s.ds.Artist(ctx).CountAll()
+	data.Library.Playlists, _ = s.ds.Playlist(ctx).Count()
+	data.Library.Shares, _ = s.ds.Share(ctx).CountAll()
+	data.Library.Radios, _ = s.ds.Radio(ctx).Count()
+	data.Library.ActiveUsers, _ = s.ds.User(ctx).CountAll(model.QueryOptions{
+		Filters: squirrel.Gt{"last_access_at": time.Now().Add(-7 * 24 * time.Hour)},
+	})
+	return data
+}
+
+// snapshot assembles the full insights.Data payload, feeding both the JSON
+// telemetry endpoint and (when enabled) the Prometheus gauges from the same
+// source data.
+func (s *insightsCollector) snapshot(ctx context.Context) insights.Data {
 	data := staticData()
 	data.Uptime = time.Since(consts.ServerStart).Milliseconds() / 1000
+
 	libraryUpdate.Do(func() {
-		data.Library.Tracks, _ = s.ds.MediaFile(ctx).CountAll()
-		data.Library.Albums, _ = s.ds.Album(ctx).CountAll()
-		data.Library.Artists, _ = s.ds.Artist(ctx).CountAll()
-		data.Library.Playlists, _ = s.ds.Playlist(ctx).Count()
-		data.Library.Shares, _ = s.ds.Share(ctx).CountAll()
-		data.Library.Radios, _ = s.ds.Radio(ctx).Count()
-		data.Library.ActiveUsers, _ = s.ds.User(ctx).CountAll(model.QueryOptions{
-			Filters: squirrel.Gt{"last_access_at": time.Now().Add(-7 * 24 * time.Hour)},
-		})
+		s.mu.Lock()
+		s.library = s.queryLibraryCounts(ctx)
+		s.mu.Unlock()
 	})
+	s.mu.Lock()
+	data.Library = s.library.Library
+	s.mu.Unlock()
+
+	return data
+}
+
+func (s *insightsCollector) Collect(ctx context.Context) string {
+	data := s.snapshot(ctx)
+	if s.prometheus != nil {
+		s.prometheus.update(data)
+	}
 
 	// Marshal to JSON
 	resp, err := json.Marshal(data)
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/navidrome/navidrome/core/metrics/insights"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// prometheusMetrics holds the gauges that mirror insights.Data on the
+// existing Prometheus endpoint, for operators who want local metrics
+// without relying on the anonymous telemetry endpoint.
+type prometheusMetrics struct {
+	libraryTracks      prometheus.Gauge
+	libraryAlbums      prometheus.Gauge
+	libraryActiveUsers prometheus.Gauge
+	uptimeSeconds      prometheus.Gauge
+	fsType             *prometheus.GaugeVec
+	featureEnabled     *prometheus.GaugeVec
+	buildInfo          *prometheus.GaugeVec
+}
+
+// promMetrics registers the gauges against the default registry exactly
+// once per process, however many times NewInsights is called (e.g. in
+// tests), so we never hit Prometheus's "duplicate collector registration"
+// panic.
+var promMetrics = sync.OnceValue(func() *prometheusMetrics {
+	return newPrometheusMetrics(prometheus.DefaultRegisterer)
+})
+
+func newPrometheusMetrics(reg prometheus.Registerer) *prometheusMetrics {
+	m := &prometheusMetrics{
+		libraryTracks: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "navidrome_library_tracks",
+			Help: "Number of tracks in the library",
+		}),
+		libraryAlbums: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "navidrome_library_albums",
+			Help: "Number of albums in the library",
+		}),
+		libraryActiveUsers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "navidrome_library_active_users",
+			Help: "Number of users active in the last 7 days",
+		}),
+		uptimeSeconds: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "navidrome_uptime_seconds",
+			Help: "Time since the server started, in seconds",
+		}),
+		fsType: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "navidrome_fs_type",
+			Help: "Filesystem type used by each configured folder, one gauge per type set to 1",
+		}, []string{"mount", "type"}),
+		featureEnabled: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "navidrome_feature_enabled",
+			Help: "Whether an optional feature is enabled (1) or not (0)",
+		}, []string{"feature"}),
+		buildInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "navidrome_build_info",
+			Help: "Static build/OS information, always set to 1",
+		}, []string{"version", "os", "arch"}),
+	}
+	reg.MustRegister(m.libraryTracks, m.libraryAlbums, m.libraryActiveUsers, m.uptimeSeconds,
+		m.fsType, m.featureEnabled, m.buildInfo)
+	return m
+}
+
+// update projects the shared insights.Data snapshot onto the Prometheus
+// gauges. It is called from the same Collect path that builds the JSON
+// telemetry payload, so the two stay in sync.
+func (m *prometheusMetrics) update(data insights.Data) {
+	m.buildInfo.Reset()
+	m.buildInfo.WithLabelValues(data.Version, data.OS.Type, data.OS.Arch).Set(1)
+
+	m.libraryTracks.Set(float64(data.Library.Tracks))
+	m.libraryAlbums.Set(float64(data.Library.Albums))
+	m.libraryActiveUsers.Set(float64(data.Library.ActiveUsers))
+	m.uptimeSeconds.Set(float64(data.Uptime))
+
+	m.fsType.Reset()
+	setFSType := func(mount string, fs *insights.FSInfo) {
+		if fs == nil {
+			return
+		}
+		m.fsType.WithLabelValues(mount, fs.Type).Set(1)
+	}
+	setFSType("music", data.FS.Music)
+	setFSType("data", data.FS.Data)
+	setFSType("cache", data.FS.Cache)
+	setFSType("backup", data.FS.Backup)
+
+	m.featureEnabled.Reset()
+	setFeature := func(name string, enabled bool) {
+		value := 0.0
+		if enabled {
+			value = 1
+		}
+		m.featureEnabled.WithLabelValues(name).Set(value)
+	}
+	setFeature("last_fm", data.Config.EnableLastFM)
+	setFeature("listen_brainz", data.Config.EnableListenBrainz)
+	setFeature("spotify", data.Config.EnableSpotify)
+	setFeature("jukebox", data.Config.EnableJukebox)
+	setFeature("sharing", data.Config.EnableSharing)
+	setFeature("downloads", data.Config.EnableDownloads)
+	setFeature("star_rating", data.Config.EnableStarRating)
+	setFeature("external_services", data.Config.EnableExternalServices)
+}
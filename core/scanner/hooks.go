@@ -0,0 +1,33 @@
+// Package scanner contains the post-scan integration points the rest of the
+// scanner (file walking, tag extraction, library bookkeeping) calls into.
+// Only those integration points live here; the scan itself is out of scope
+// for this package.
+package scanner
+
+import (
+	"context"
+
+	"github.com/navidrome/navidrome/core/metrics"
+	"github.com/navidrome/navidrome/log"
+	"github.com/navidrome/navidrome/model"
+	"github.com/navidrome/navidrome/model/metadata"
+)
+
+// OnScanFinished is called once a library finishes scanning. It refreshes
+// the Insights/Prometheus library counters immediately, so they don't lag
+// behind Collect's 10-minute refresh cadence, and runs the Spotify
+// enrichment pass over the library's media files.
+func OnScanFinished(ctx context.Context, ds model.DataStore, insights metrics.Insights, libraryID int) {
+	insights.RefreshLibraryCounts(ctx)
+	if err := metadata.ProcessLibrary(ctx, ds, libraryID); err != nil {
+		log.Error(ctx, "Error running Spotify enrichment", "library", libraryID, err)
+	}
+}
+
+// ImportFileTags resolves a single file's raw tag frames into Navidrome's
+// internal tags, honoring the library's mapping overlay and configured
+// transforms. The file walker calls this once per discovered file, before
+// building the model.MediaFile that gets persisted.
+func ImportFileTags(ctx context.Context, libraryID int, raw map[string][]string) model.Tags {
+	return metadata.MapTags(ctx, libraryID, raw)
+}
@@ -0,0 +1,241 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/core/agents/spotify"
+	"github.com/navidrome/navidrome/log"
+	"github.com/navidrome/navidrome/model"
+)
+
+// spotifyClient is the subset of spotify.Client used by the enricher,
+// extracted as an interface so tests can fake it.
+type spotifyClient interface {
+	SearchTrack(ctx context.Context, artist, title, isrc string) (spotify.Track, error)
+	Genres(ctx context.Context, artistID string) ([]string, error)
+	AudioFeatures(ctx context.Context, ids []string) ([]spotify.AudioFeatures, error)
+}
+
+var (
+	realSpotifyClientOnce sync.Once
+	realSpotifyClient     spotifyClient
+)
+
+// newSpotifyClient returns the Spotify client used for enrichment, building
+// it (at most once) from the configured credentials. It's a reassignable
+// package variable, rather than a plain function, so tests can swap in a
+// fake instead of going through the real HTTP client.
+var newSpotifyClient = func() spotifyClient {
+	realSpotifyClientOnce.Do(func() {
+		if conf.Server.Spotify.ID == "" || conf.Server.Spotify.Secret == "" {
+			return
+		}
+		realSpotifyClient = spotify.NewClient(conf.Server.Spotify.ID, conf.Server.Spotify.Secret, nil)
+	})
+	return realSpotifyClient
+}
+
+const spotifyIDTag model.TagName = "spotify_id"
+
+// ProcessLibrary is the Spotify enrichment entry point for the scan
+// pipeline: call it once a library finishes scanning. It loads every media
+// file in the library, enriches whatever is missing Spotify-derived tags,
+// and persists the result back to the datastore so a subsequent scan won't
+// re-query tracks that are already enriched.
+func ProcessLibrary(ctx context.Context, ds model.DataStore, libraryID int) error {
+	if conf.Server.Spotify.ID == "" || conf.Server.Spotify.Secret == "" {
+		return nil
+	}
+
+	mfs, err := ds.MediaFile(ctx).GetAll(model.QueryOptions{Filters: squirrel.Eq{"library_id": libraryID}})
+	if err != nil {
+		return fmt.Errorf("loading media files for library %d: %w", libraryID, err)
+	}
+
+	ptrs := make([]*model.MediaFile, len(mfs))
+	for i := range mfs {
+		ptrs[i] = &mfs[i]
+	}
+
+	for _, mf := range EnrichWithSpotify(ctx, ptrs) {
+		if err := ds.MediaFile(ctx).Put(mf); err != nil {
+			log.Error(ctx, "Error persisting Spotify enrichment", "id", mf.ID, err)
+		}
+	}
+	return nil
+}
+
+// EnrichWithSpotify looks up each track's Spotify ID (if not already known)
+// and merges its audio features into the track's tags, according to the
+// `enrichment.spotify` section of mappings.yaml. It is a no-op when the
+// Spotify integration is not configured. Tracks that already carry the
+// configured tags (persisted by a previous call) are left untouched, so
+// rescans don't re-query the Spotify API for data we already have. It
+// returns the subset of mfs that were actually changed, so callers only need
+// to persist those.
+func EnrichWithSpotify(ctx context.Context, mfs []*model.MediaFile) []*model.MediaFile {
+	client := newSpotifyClient()
+	if client == nil {
+		return nil
+	}
+	cfg := spotifyEnrichmentConfig()
+
+	changed := map[*model.MediaFile]bool{}
+	idToTrack := map[string]*model.MediaFile{}
+	for _, mf := range mfs {
+		id := mf.Tags.Get(spotifyIDTag)
+		if len(id) == 0 {
+			if id = lookupSpotifyID(ctx, client, mf, cfg); len(id) > 0 {
+				changed[mf] = true
+			}
+		}
+		if len(id) == 0 {
+			continue
+		}
+		if !needsAudioFeatures(mf, cfg) {
+			continue
+		}
+		idToTrack[id[0]] = mf
+	}
+
+	if len(idToTrack) > 0 {
+		ids := make([]string, 0, len(idToTrack))
+		for id := range idToTrack {
+			ids = append(ids, id)
+		}
+		for _, batch := range batchIDs(ids, 100) {
+			features, err := client.AudioFeatures(ctx, batch)
+			if err != nil {
+				log.Error(ctx, "Error fetching Spotify audio features", "ids", batch, err)
+				continue
+			}
+			for _, f := range features {
+				mf := idToTrack[f.ID]
+				if mf == nil {
+					continue
+				}
+				applyAudioFeatures(mf, f, cfg)
+				changed[mf] = true
+			}
+		}
+	}
+
+	result := make([]*model.MediaFile, 0, len(changed))
+	for _, mf := range mfs {
+		if changed[mf] {
+			result = append(result, mf)
+		}
+	}
+	return result
+}
+
+// needsAudioFeatures reports whether mf is missing any of the audio-feature
+// tags the user configured, meaning it still needs an /audio-features call.
+func needsAudioFeatures(mf *model.MediaFile, cfg spotifyEnrichmentConf) bool {
+	for _, tag := range []model.TagName{
+		cfg.Danceability, cfg.Energy, cfg.Tempo, cfg.Valence,
+		cfg.Acousticness, cfg.Loudness, cfg.Key, cfg.Mode, cfg.TimeSignature,
+	} {
+		if tag != "" && len(mf.Tags.Get(tag)) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupSpotifyID searches for mf on Spotify by artist/title (or ISRC when
+// available), caching the track ID and, while we have the track and artist
+// at hand, the popularity and genre tags too.
+func lookupSpotifyID(ctx context.Context, client spotifyClient, mf *model.MediaFile, cfg spotifyEnrichmentConf) []string {
+	artist := mf.Tags.Get("artist")
+	title := mf.Tags.Get("title")
+	if len(artist) == 0 || len(title) == 0 {
+		return nil
+	}
+	isrc := mf.Tags.Get("isrc")
+	var isrcValue string
+	if len(isrc) > 0 {
+		isrcValue = isrc[0]
+	}
+	track, err := client.SearchTrack(ctx, artist[0], title[0], isrcValue)
+	if err != nil {
+		log.Error(ctx, "Error searching Spotify track", "artist", artist[0], "title", title[0], err)
+		return nil
+	}
+	if track.ID == "" {
+		return nil
+	}
+	mf.Tags.Add(spotifyIDTag, track.ID)
+
+	if cfg.Popularity != "" && len(mf.Tags.Get(cfg.Popularity)) == 0 {
+		mf.Tags.Add(cfg.Popularity, strconv.Itoa(track.Popularity))
+	}
+	if cfg.Genres != "" && len(mf.Tags.Get(cfg.Genres)) == 0 {
+		genres, err := client.Genres(ctx, track.ArtistID())
+		if err != nil {
+			log.Error(ctx, "Error fetching Spotify artist genres", "artistId", track.ArtistID(), err)
+		}
+		for _, genre := range genres {
+			mf.Tags.Add(cfg.Genres, genre)
+		}
+	}
+
+	return []string{track.ID}
+}
+
+// applyAudioFeatures merges a Spotify audio-features response into mf's
+// tags. Fields mapped to a tag configured as TagTypeNormalizedFloat (e.g.
+// danceability, energy, valence, acousticness, which Spotify already scales
+// 0..1) are clamped defensively; tempo/loudness are genuinely unbounded and
+// are stored as-is.
+func applyAudioFeatures(mf *model.MediaFile, f spotify.AudioFeatures, cfg spotifyEnrichmentConf) {
+	tagTypes := mappings()
+	setFloat := func(tag model.TagName, value float64) {
+		if tag == "" {
+			return
+		}
+		if tagTypes[tag].Type == TagTypeNormalizedFloat {
+			value = clamp01(value)
+		}
+		mf.Tags.Add(tag, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	setInt := func(tag model.TagName, value int) {
+		if tag == "" {
+			return
+		}
+		mf.Tags.Add(tag, strconv.Itoa(value))
+	}
+	setFloat(cfg.Danceability, f.Danceability)
+	setFloat(cfg.Energy, f.Energy)
+	setFloat(cfg.Tempo, f.Tempo)
+	setFloat(cfg.Valence, f.Valence)
+	setFloat(cfg.Acousticness, f.Acousticness)
+	setFloat(cfg.Loudness, f.Loudness)
+	setInt(cfg.Key, f.Key)
+	setInt(cfg.Mode, f.Mode)
+	setInt(cfg.TimeSignature, f.TimeSignature)
+}
+
+func clamp01(v float64) float64 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 1:
+		return 1
+	default:
+		return v
+	}
+}
+
+func batchIDs(ids []string, size int) [][]string {
+	var batches [][]string
+	for size < len(ids) {
+		ids, batches = ids[size:], append(batches, ids[:size:size])
+	}
+	return append(batches, ids)
+}
@@ -0,0 +1,171 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+
+	"github.com/navidrome/navidrome/core/agents/spotify"
+	"github.com/navidrome/navidrome/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeSpotifyClient is a spotifyClient double driven entirely by its fields,
+// so each test only needs to set up what it actually exercises.
+type fakeSpotifyClient struct {
+	track       spotify.Track
+	searchErr   error
+	genres      []string
+	genresErr   error
+	features    []spotify.AudioFeatures
+	featuresErr error
+
+	searchCalls int
+	genresCalls int
+}
+
+func (f *fakeSpotifyClient) SearchTrack(_ context.Context, _, _, _ string) (spotify.Track, error) {
+	f.searchCalls++
+	return f.track, f.searchErr
+}
+
+func (f *fakeSpotifyClient) Genres(_ context.Context, _ string) ([]string, error) {
+	f.genresCalls++
+	return f.genres, f.genresErr
+}
+
+func (f *fakeSpotifyClient) AudioFeatures(_ context.Context, _ []string) ([]spotify.AudioFeatures, error) {
+	return f.features, f.featuresErr
+}
+
+var _ = Describe("EnrichWithSpotify", func() {
+	var (
+		client *fakeSpotifyClient
+		mf     *model.MediaFile
+	)
+
+	BeforeEach(func() {
+		client = &fakeSpotifyClient{
+			track: spotify.Track{ID: "track1", Popularity: 42},
+		}
+		newSpotifyClient = func() spotifyClient { return client }
+		mf = &model.MediaFile{
+			ID:   "1",
+			Tags: model.Tags{},
+		}
+		mf.Tags.Add("artist", "Pink Floyd")
+		mf.Tags.Add("title", "Echoes")
+	})
+
+	AfterEach(func() {
+		newSpotifyClient = func() spotifyClient { return nil }
+	})
+
+	It("is a no-op when no client is configured", func() {
+		newSpotifyClient = func() spotifyClient { return nil }
+		Expect(EnrichWithSpotify(context.Background(), []*model.MediaFile{mf})).To(BeEmpty())
+		Expect(mf.Tags.Get(spotifyIDTag)).To(BeEmpty())
+	})
+
+	It("looks up and caches the Spotify ID, popularity and genres", func() {
+		client.genres = []string{"prog rock", "psychedelic rock"}
+
+		changed := EnrichWithSpotify(context.Background(), []*model.MediaFile{mf})
+
+		Expect(changed).To(ConsistOf(mf))
+		Expect(mf.Tags.Get(spotifyIDTag)).To(ConsistOf("track1"))
+		Expect(mf.Tags.Get("spotify_popularity")).To(ConsistOf("42"))
+		Expect(mf.Tags.Get("spotify_genres")).To(ConsistOf("prog rock", "psychedelic rock"))
+	})
+
+	It("skips the search when a Spotify ID is already cached", func() {
+		mf.Tags.Add(spotifyIDTag, "cached-id")
+		// Give it audio features to fetch, so EnrichWithSpotify has work to do
+		// beyond the (skipped) search.
+		client.features = []spotify.AudioFeatures{{ID: "cached-id", Danceability: 0.5}}
+
+		EnrichWithSpotify(context.Background(), []*model.MediaFile{mf})
+
+		Expect(client.searchCalls).To(Equal(0))
+	})
+
+	It("merges audio features for tracks that need them", func() {
+		client.features = []spotify.AudioFeatures{
+			{ID: "track1", Danceability: 0.8, Tempo: 120, Key: 5, Mode: 1, TimeSignature: 4},
+		}
+
+		changed := EnrichWithSpotify(context.Background(), []*model.MediaFile{mf})
+
+		Expect(changed).To(ConsistOf(mf))
+		Expect(mf.Tags.Get("spotify_danceability")).To(ConsistOf("0.8"))
+		Expect(mf.Tags.Get("spotify_tempo")).To(ConsistOf("120"))
+		Expect(mf.Tags.Get("spotify_key")).To(ConsistOf("5"))
+	})
+
+	It("clamps normalizedFloat tags but leaves plain floats untouched", func() {
+		client.features = []spotify.AudioFeatures{
+			{ID: "track1", Danceability: 1.5, Loudness: -12.3},
+		}
+
+		EnrichWithSpotify(context.Background(), []*model.MediaFile{mf})
+
+		Expect(mf.Tags.Get("spotify_danceability")).To(ConsistOf("1"))
+		Expect(mf.Tags.Get("spotify_loudness")).To(ConsistOf("-12.3"))
+	})
+
+	It("reports no change when the track can't be found", func() {
+		client.track = spotify.Track{}
+
+		changed := EnrichWithSpotify(context.Background(), []*model.MediaFile{mf})
+
+		Expect(changed).To(BeEmpty())
+		Expect(mf.Tags.Get(spotifyIDTag)).To(BeEmpty())
+	})
+
+	It("reports no change when the search fails", func() {
+		client.searchErr = errors.New("boom")
+
+		changed := EnrichWithSpotify(context.Background(), []*model.MediaFile{mf})
+
+		Expect(changed).To(BeEmpty())
+	})
+
+	It("leaves already-enriched, fully-tagged tracks untouched", func() {
+		mf.Tags.Add(spotifyIDTag, "track1")
+		for _, tag := range []model.TagName{
+			"spotify_danceability", "spotify_energy", "spotify_tempo", "spotify_valence",
+			"spotify_acousticness", "spotify_loudness", "spotify_key", "spotify_mode",
+			"spotify_time_signature",
+		} {
+			mf.Tags.Add(tag, "0")
+		}
+
+		changed := EnrichWithSpotify(context.Background(), []*model.MediaFile{mf})
+
+		Expect(changed).To(BeEmpty())
+	})
+})
+
+var _ = Describe("clamp01", func() {
+	It("clamps values outside 0..1 and leaves others untouched", func() {
+		Expect(clamp01(-0.5)).To(Equal(0.0))
+		Expect(clamp01(1.5)).To(Equal(1.0))
+		Expect(clamp01(0.42)).To(Equal(0.42))
+	})
+})
+
+var _ = Describe("batchIDs", func() {
+	It("splits ids into batches no larger than size", func() {
+		ids := []string{"1", "2", "3", "4", "5"}
+		Expect(batchIDs(ids, 2)).To(Equal([][]string{{"1", "2"}, {"3", "4"}, {"5"}}))
+	})
+
+	It("returns a single batch when ids fit within size", func() {
+		ids := []string{"1", "2"}
+		Expect(batchIDs(ids, 100)).To(Equal([][]string{{"1", "2"}}))
+	})
+
+	It("returns a single empty batch for no ids", func() {
+		Expect(batchIDs(nil, 100)).To(Equal([][]string{nil}))
+	})
+})
@@ -1,38 +1,94 @@
 package metadata
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 
+	"github.com/navidrome/navidrome/conf"
 	"github.com/navidrome/navidrome/log"
 	"github.com/navidrome/navidrome/model"
 	"github.com/navidrome/navidrome/resources"
 	"gopkg.in/yaml.v3"
 )
 
+// userMappingsFile is the name of the optional, user-provided mappings file
+// that is merged on top of the embedded one. It lives directly in the
+// configured data folder so it survives upgrades.
+const userMappingsFile = "mappings.yaml"
+
 type mappingsConf struct {
 	Main       tagMappings `yaml:"main"`
 	Additional tagMappings `yaml:"additional"`
 	Roles      tagConf     `yaml:"roles"`
 	Artists    tagConf     `yaml:"artists"`
+	Enrichment enrichment  `yaml:"enrichment"`
+
+	// Remove lists tag names (as they appear in `main`/`additional`) that an
+	// overlay wants to disable from whatever was merged before it, e.g. a
+	// user file turning off an alias shipped in the embedded mappings.
+	Remove []model.TagName `yaml:"remove"`
+}
+
+// enrichment configures third-party data sources that augment parsed tags
+// after the base mappings have been applied.
+type enrichment struct {
+	Spotify spotifyEnrichmentConf `yaml:"spotify"`
+}
+
+// spotifyEnrichmentConf maps Spotify audio-features/track fields to the tag
+// names they should be imported as. A field is only imported if it has a
+// non-empty entry here, letting users opt in to just the fields they want.
+type spotifyEnrichmentConf struct {
+	Danceability  model.TagName `yaml:"danceability"`
+	Energy        model.TagName `yaml:"energy"`
+	Tempo         model.TagName `yaml:"tempo"`
+	Valence       model.TagName `yaml:"valence"`
+	Acousticness  model.TagName `yaml:"acousticness"`
+	Loudness      model.TagName `yaml:"loudness"`
+	Key           model.TagName `yaml:"key"`
+	Mode          model.TagName `yaml:"mode"`
+	TimeSignature model.TagName `yaml:"time_signature"`
+	Popularity    model.TagName `yaml:"popularity"`
+	Genres        model.TagName `yaml:"genres"`
 }
 
 type tagMappings map[model.TagName]tagConf
 
+// tagConf describes how a single tag is extracted and, optionally,
+// transformed. Transforms that run (when present) are applied in this
+// fixed order: Trim, Normalize, Regex, Replace, Split, Template, Default.
+// Template and Default only kick in once every other step has produced an
+// empty value, since they exist to fill gaps rather than reshape data.
 type tagConf struct {
-	Aliases   []string `yaml:"aliases"`
-	Type      TagType  `yaml:"type"`
-	MaxLength int      `yaml:"maxLength"`
-	Split     []string `yaml:"split"`
+	Aliases   []string      `yaml:"aliases"`
+	Type      TagType       `yaml:"type"`
+	MaxLength int           `yaml:"maxLength"`
+	Split     []string      `yaml:"split"`
+	Regex     string        `yaml:"regex"`
+	Replace   []replacement `yaml:"replace"`
+	Trim      bool          `yaml:"trim"`
+	Normalize string        `yaml:"normalize"`
+	Default   string        `yaml:"default"`
+	Template  string        `yaml:"template"`
+}
+
+// replacement is a single {from,to} pair used by tagConf.Replace.
+type replacement struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
 }
 
 type TagType string
 
 const (
-	TagTypeInteger TagType = "integer"
-	TagTypeFloat   TagType = "float"
-	TagTypeDate    TagType = "date"
-	TagTypeUUID    TagType = "uuid"
+	TagTypeInteger         TagType = "integer"
+	TagTypeFloat           TagType = "float"
+	TagTypeNormalizedFloat TagType = "normalizedFloat" // float clamped to the 0..1 range
+	TagTypeDate            TagType = "date"
+	TagTypeUUID            TagType = "uuid"
 )
 
 func mappings() map[model.TagName]tagConf {
@@ -50,22 +106,112 @@ func artistsConf() tagConf {
 	return conf.Artists
 }
 
+func spotifyEnrichmentConfig() spotifyEnrichmentConf {
+	_, conf := parseMappings()
+	return conf.Enrichment.Spotify
+}
+
 var parseMappings = sync.OnceValues(func() (map[model.TagName]tagConf, mappingsConf) {
+	merged := loadEmbeddedMappings()
+
+	if overlay, ok := loadUserMappings(filepath.Join(conf.Server.DataFolder, userMappingsFile)); ok {
+		merged = mergeMappingsConf(merged, overlay)
+	}
+
+	normalized := tagMappings{}
+	collectTags(merged.Main, normalized)
+	collectTags(merged.Additional, normalized)
+	return normalized, merged
+})
+
+func loadEmbeddedMappings() mappingsConf {
 	mappingsFile, err := resources.FS().Open("mappings.yaml")
 	if err != nil {
 		log.Error("Error opening mappings.yaml", err)
 	}
-	decoder := yaml.NewDecoder(mappingsFile)
 	var mappings mappingsConf
-	err = decoder.Decode(&mappings)
+	err = yaml.NewDecoder(mappingsFile).Decode(&mappings)
 	if err != nil {
 		log.Error("Error decoding mappings.yaml", err)
 	}
+	return mappings
+}
+
+// loadUserMappings reads an optional overlay file, returning ok=false if it
+// does not exist (which is not an error, as the file is entirely optional).
+func loadUserMappings(path string) (mappingsConf, bool) {
+	var overlay mappingsConf
+	file, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Error("Error opening user mappings file", "path", path, err)
+		}
+		return overlay, false
+	}
+	defer file.Close()
+
+	if err := yaml.NewDecoder(file).Decode(&overlay); err != nil {
+		log.Error("Error decoding user mappings file", "path", path, err)
+		return overlay, false
+	}
+	return overlay, true
+}
+
+// mergeLibraryOverlay layers a per-library mappings overlay (as raw YAML) on
+// top of the already-merged embedded+user mappings, without changing the
+// global configuration used by other libraries.
+func mergeLibraryOverlay(overlayYAML []byte) (map[model.TagName]tagConf, error) {
+	var overlay mappingsConf
+	if err := yaml.Unmarshal(overlayYAML, &overlay); err != nil {
+		return nil, err
+	}
+	_, base := parseMappings()
+	merged := mergeMappingsConf(base, overlay)
+
 	normalized := tagMappings{}
-	collectTags(mappings.Main, normalized)
-	collectTags(mappings.Additional, normalized)
-	return normalized, mappings
-})
+	collectTags(merged.Main, normalized)
+	collectTags(merged.Additional, normalized)
+	return normalized, nil
+}
+
+// mergeMappingsConf layers overlay on top of base: tags are merged key by
+// key (overlay wins on conflicts), Roles/Artists/Enrichment are replaced
+// wholesale when set, and any tag listed in overlay.Remove is dropped from
+// the result even if base defined it.
+func mergeMappingsConf(base, overlay mappingsConf) mappingsConf {
+	merged := mappingsConf{
+		Main:       mergeTagMappings(base.Main, overlay.Main),
+		Additional: mergeTagMappings(base.Additional, overlay.Additional),
+		Roles:      base.Roles,
+		Artists:    base.Artists,
+		Enrichment: base.Enrichment,
+	}
+	if !reflect.DeepEqual(overlay.Roles, tagConf{}) {
+		merged.Roles = overlay.Roles
+	}
+	if !reflect.DeepEqual(overlay.Artists, tagConf{}) {
+		merged.Artists = overlay.Artists
+	}
+	if overlay.Enrichment.Spotify != (spotifyEnrichmentConf{}) {
+		merged.Enrichment.Spotify = overlay.Enrichment.Spotify
+	}
+	for _, tag := range overlay.Remove {
+		delete(merged.Main, tag)
+		delete(merged.Additional, tag)
+	}
+	return merged
+}
+
+func mergeTagMappings(base, overlay tagMappings) tagMappings {
+	merged := tagMappings{}
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overlay {
+		merged[k] = v
+	}
+	return merged
+}
 
 func collectTags(tagMappings, normalized map[model.TagName]tagConf) {
 	for k, v := range tagMappings {
@@ -77,6 +223,23 @@ func collectTags(tagMappings, normalized map[model.TagName]tagConf) {
 			log.Error("Tag splitting only available for string types", "tag", k, "split", v.Split, "type", v.Type)
 			v.Split = nil
 		}
-		normalized[k.ToLower()] = tagConf{Aliases: aliases, Type: v.Type, MaxLength: v.MaxLength, Split: v.Split}
+		if v.Type != "" {
+			if v.Regex != "" || v.Replace != nil || v.Trim || v.Normalize != "" || v.Template != "" {
+				log.Error("Tag transforms only available for string types", "tag", k, "type", v.Type)
+				v.Regex, v.Replace, v.Trim, v.Normalize, v.Template = "", nil, false, "", ""
+			}
+		}
+		normalized[k.ToLower()] = tagConf{
+			Aliases:   aliases,
+			Type:      v.Type,
+			MaxLength: v.MaxLength,
+			Split:     v.Split,
+			Regex:     v.Regex,
+			Replace:   v.Replace,
+			Trim:      v.Trim,
+			Normalize: v.Normalize,
+			Default:   v.Default,
+			Template:  v.Template,
+		}
 	}
 }
@@ -0,0 +1,114 @@
+package metadata
+
+import (
+	"github.com/navidrome/navidrome/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("mappingsConf merging", func() {
+	var base mappingsConf
+
+	BeforeEach(func() {
+		base = mappingsConf{
+			Main: tagMappings{
+				"title":       tagConf{Aliases: []string{"title"}},
+				"discnumber":  tagConf{Aliases: []string{"discnumber"}},
+				"tracknumber": tagConf{Aliases: []string{"tracknumber"}},
+			},
+		}
+	})
+
+	It("loads and applies a user overlay fixture", func() {
+		overlay, ok := loadUserMappings("testdata/user-mappings.yaml")
+		Expect(ok).To(BeTrue())
+
+		merged := mergeMappingsConf(base, overlay)
+
+		By("adding a new tag")
+		Expect(merged.Main).To(HaveKey(model.TagName("albumartist")))
+
+		By("overriding an existing tag")
+		Expect(merged.Main["tracknumber"].Regex).To(Equal(`^(\d+)`))
+
+		By("removing a tag listed under remove:")
+		Expect(merged.Main).NotTo(HaveKey(model.TagName("discnumber")))
+
+		By("leaving untouched tags alone")
+		Expect(merged.Main).To(HaveKey(model.TagName("title")))
+	})
+
+	It("returns ok=false for a missing overlay file", func() {
+		_, ok := loadUserMappings("testdata/does-not-exist.yaml")
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("collectTags", func() {
+	It("gates Split and the new transforms to string-typed tags", func() {
+		in := tagMappings{
+			"year": tagConf{Type: TagTypeInteger, Split: []string{"/"}, Trim: true, Regex: "(\\d+)"},
+		}
+		out := tagMappings{}
+		collectTags(in, out)
+
+		conf := out["year"]
+		Expect(conf.Split).To(BeNil())
+		Expect(conf.Trim).To(BeFalse())
+		Expect(conf.Regex).To(BeEmpty())
+	})
+
+	It("lower-cases tag names and aliases", func() {
+		in := tagMappings{
+			"AlbumArtist": tagConf{Aliases: []string{"TPE2"}},
+		}
+		out := tagMappings{}
+		collectTags(in, out)
+
+		Expect(out).To(HaveKey(model.TagName("albumartist")))
+		Expect(out["albumartist"].Aliases).To(ConsistOf("tpe2"))
+	})
+})
+
+var _ = Describe("applyScalarTransforms", func() {
+	It("applies transforms in a stable order: trim, normalize, regex, replace", func() {
+		conf := tagConf{
+			Trim:      true,
+			Normalize: "upper",
+			Regex:     `^(TRACK-\d+)`,
+			Replace:   []replacement{{From: "TRACK-", To: "T"}},
+		}
+		got := applyScalarTransforms("custom", "  track-007 extra  ", conf)
+		Expect(got).To(Equal("T007"))
+	})
+
+	It("leaves a non-empty value untouched when no transforms are configured", func() {
+		Expect(applyScalarTransforms("title", "Echoes", tagConf{})).To(Equal("Echoes"))
+	})
+})
+
+var _ = Describe("splitValue", func() {
+	It("splits on a single separator, trimming and dropping empty parts", func() {
+		Expect(splitValue("Roger Waters / David Gilmour", []string{"/"})).
+			To(Equal([]string{"Roger Waters", "David Gilmour"}))
+	})
+
+	It("splits on multiple separators", func() {
+		Expect(splitValue("a/b;c", []string{"/", ";"})).To(Equal([]string{"a", "b", "c"}))
+	})
+
+	It("returns the value unchanged when no separators are configured", func() {
+		Expect(splitValue("a/b", nil)).To(Equal([]string{"a/b"}))
+	})
+})
+
+var _ = Describe("fallbackValue", func() {
+	It("falls back to a template, then to a default", func() {
+		conf := tagConf{Template: "{{.artist}}"}
+		others := map[model.TagName][]string{"artist": {"Pink Floyd"}}
+		Expect(fallbackValue("albumartist", conf, others)).To(Equal("Pink Floyd"))
+
+		confWithDefault := tagConf{Template: "{{.missing}}", Default: "Unknown"}
+		Expect(fallbackValue("albumartist", confWithDefault, nil)).To(Equal("Unknown"))
+	})
+})
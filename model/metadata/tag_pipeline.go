@@ -0,0 +1,117 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/log"
+	"github.com/navidrome/navidrome/model"
+)
+
+// libraryMappingsFile names the per-library mappings overlay, layered on
+// top of the global (embedded + user) mappings for a single library.
+func libraryMappingsFile(libraryID int) string {
+	return filepath.Join(conf.Server.DataFolder, fmt.Sprintf("mappings-library-%d.yaml", libraryID))
+}
+
+// mappingsForLibrary returns the tag mappings to use for a given library,
+// merging its overlay file (if any) on top of the global mappings.
+func mappingsForLibrary(libraryID int) map[model.TagName]tagConf {
+	path := libraryMappingsFile(libraryID)
+	overlayYAML, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Error("Error reading per-library mappings overlay", "library", libraryID, "path", path, err)
+		}
+		return mappings()
+	}
+	merged, err := mergeLibraryOverlay(overlayYAML)
+	if err != nil {
+		log.Error("Error parsing per-library mappings overlay", "library", libraryID, "path", path, err)
+		return mappings()
+	}
+	return merged
+}
+
+// MapTags is the tag-mapping application step the scanner calls once it has
+// read a file's raw tag frames: for every configured tag, it resolves the
+// tag's aliases against the raw frames (case-insensitively) and applies any
+// configured transforms, in the order documented on tagConf.
+//
+// Tags are processed in two passes so Template expressions can rely on
+// other tags being already resolved: plain tags first, then templated tags
+// (sorted by name, for a deterministic result when more than one is
+// configured). This is the "stable ordering" guarantee the mappings system
+// depends on.
+func MapTags(ctx context.Context, libraryID int, raw map[string][]string) model.Tags {
+	conf := mappingsForLibrary(libraryID)
+
+	lowerRaw := make(map[string][]string, len(raw))
+	for k, v := range raw {
+		lowerRaw[strings.ToLower(k)] = v
+	}
+
+	tags := model.Tags{}
+	var templated []model.TagName
+	for tagName, c := range conf {
+		if c.Template != "" {
+			templated = append(templated, tagName)
+			continue
+		}
+		applyTagConf(tags, lowerRaw, tagName, c)
+	}
+	sort.Slice(templated, func(i, j int) bool { return templated[i] < templated[j] })
+	for _, tagName := range templated {
+		applyTagConf(tags, lowerRaw, tagName, conf[tagName])
+	}
+	return tags
+}
+
+func applyTagConf(tags model.Tags, raw map[string][]string, tagName model.TagName, c tagConf) {
+	raws := valuesForAliases(raw, c.Aliases)
+
+	var values []string
+	for _, v := range raws {
+		v = applyScalarTransforms(tagName, v, c)
+		if v == "" {
+			continue
+		}
+		values = append(values, splitValue(v, c.Split)...)
+	}
+
+	if len(values) == 0 {
+		if v := fallbackValue(tagName, c, tags); v != "" {
+			values = []string{v}
+		}
+	}
+
+	for _, v := range values {
+		if v != "" {
+			tags.Add(tagName, v)
+		}
+	}
+}
+
+// fallbackValue resolves a tag's Template (falling back to Default) when no
+// raw value produced anything, per the order documented on tagConf.
+func fallbackValue(tagName model.TagName, c tagConf, others map[model.TagName][]string) string {
+	if c.Template != "" {
+		if v := applyTemplate(tagName, c.Template, others); v != "" {
+			return v
+		}
+	}
+	return c.Default
+}
+
+func valuesForAliases(raw map[string][]string, aliases []string) []string {
+	var values []string
+	for _, alias := range aliases {
+		values = append(values, raw[alias]...)
+	}
+	return values
+}
@@ -0,0 +1,66 @@
+package metadata
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/navidrome/navidrome/conf"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MapTags (full pipeline)", func() {
+	var originalDataFolder string
+
+	BeforeEach(func() {
+		originalDataFolder = conf.Server.DataFolder
+		conf.Server.DataFolder = GinkgoT().TempDir()
+	})
+
+	AfterEach(func() {
+		conf.Server.DataFolder = originalDataFolder
+	})
+
+	It("merges the per-library overlay and applies transforms in a stable, deterministic order", func() {
+		overlay, err := os.ReadFile("testdata/library-mappings.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		overlayPath := filepath.Join(conf.Server.DataFolder, "mappings-library-7.yaml")
+		Expect(os.WriteFile(overlayPath, overlay, 0o600)).To(Succeed())
+
+		raw := map[string][]string{
+			"tpe1": {"  Pink Floyd  "},
+			"tit2": {"Echoes"},
+			"trck": {"1/12"},
+			"tcon": {"Prog Rock/Psychedelic Rock"},
+		}
+
+		tags := MapTags(context.Background(), 7, raw)
+
+		By("trimming the artist tag")
+		Expect(tags.Get("artist")).To(ConsistOf("Pink Floyd"))
+
+		By("extracting the leading number via regex")
+		Expect(tags.Get("tracknumber")).To(ConsistOf("1"))
+
+		By("falling back to the artist via a template, once artist has been resolved")
+		Expect(tags.Get("albumartist")).To(ConsistOf("Pink Floyd"))
+
+		By("splitting the genre tag on its configured separators")
+		Expect(tags.Get("genre")).To(ConsistOf("Prog Rock", "Psychedelic Rock"))
+	})
+
+	It("produces the same result across repeated runs", func() {
+		overlay, err := os.ReadFile("testdata/library-mappings.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		overlayPath := filepath.Join(conf.Server.DataFolder, "mappings-library-7.yaml")
+		Expect(os.WriteFile(overlayPath, overlay, 0o600)).To(Succeed())
+
+		raw := map[string][]string{"tpe1": {"Air"}, "trck": {"3/9"}}
+
+		first := MapTags(context.Background(), 7, raw)
+		second := MapTags(context.Background(), 7, raw)
+		Expect(first.Get("albumartist")).To(Equal(second.Get("albumartist")))
+		Expect(first.Get("tracknumber")).To(Equal(second.Get("tracknumber")))
+	})
+})
@@ -0,0 +1,122 @@
+package metadata
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/navidrome/navidrome/log"
+	"github.com/navidrome/navidrome/model"
+)
+
+// applyScalarTransforms runs a tag's per-value configured transforms against
+// value, in the fixed order documented on tagConf: Trim, Normalize, Regex,
+// Replace. Split, Template and Default are applied one level up, in
+// applyTagConf, since they operate on the whole list of values for a tag
+// rather than a single string.
+func applyScalarTransforms(tag model.TagName, value string, conf tagConf) string {
+	if conf.Trim {
+		value = strings.TrimSpace(value)
+	}
+	if conf.Normalize != "" {
+		value = normalizeValue(value, conf.Normalize)
+	}
+	if conf.Regex != "" {
+		value = applyRegex(tag, value, conf.Regex)
+	}
+	for _, r := range conf.Replace {
+		value = strings.ReplaceAll(value, r.From, r.To)
+	}
+	return value
+}
+
+// splitValue splits value on each of seps in turn (so e.g. `["/", ";"]`
+// splits on either separator), trimming and discarding empty parts. It
+// returns a single-element slice holding value unchanged when no separators
+// are configured.
+func splitValue(value string, seps []string) []string {
+	if value == "" || len(seps) == 0 {
+		return []string{value}
+	}
+	parts := []string{value}
+	for _, sep := range seps {
+		var next []string
+		for _, p := range parts {
+			next = append(next, strings.Split(p, sep)...)
+		}
+		parts = next
+	}
+	values := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			values = append(values, p)
+		}
+	}
+	return values
+}
+
+func normalizeValue(value, mode string) string {
+	switch strings.ToLower(mode) {
+	case "nfc":
+		return norm.NFC.String(value)
+	case "nfd":
+		return norm.NFD.String(value)
+	case "upper":
+		return strings.ToUpper(value)
+	case "lower":
+		return strings.ToLower(value)
+	default:
+		log.Error("Unknown tag normalize mode", "mode", mode)
+		return value
+	}
+}
+
+// applyRegex extracts the first capture group of expr from value. If expr
+// has no capture group, the whole match is used. An empty string is
+// returned if expr does not match; if expr fails to compile, an error is
+// logged and value is returned unchanged.
+func applyRegex(tag model.TagName, value, expr string) string {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		log.Error("Invalid tag regex", "tag", tag, "regex", expr, err)
+		return value
+	}
+	match := re.FindStringSubmatch(value)
+	if match == nil {
+		return ""
+	}
+	if len(match) > 1 {
+		return match[1]
+	}
+	return match[0]
+}
+
+// applyTemplate renders a tagConf.Template expression against the other
+// already-parsed tags, e.g. `{{.artist}}` to fall back to the artist when a
+// tag such as albumartist is missing. Only the first value of each
+// referenced tag is exposed to the template.
+func applyTemplate(tag model.TagName, tmpl string, others map[model.TagName][]string) string {
+	data := make(map[string]string, len(others))
+	for name, values := range others {
+		if len(values) > 0 {
+			data[string(name)] = values[0]
+		}
+	}
+
+	// missingkey=zero renders a reference to an absent tag as "" (instead of
+	// "<no value>"), so a template over missing tags falls back to Default.
+	t, err := template.New(string(tag)).Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		log.Error("Invalid tag template", "tag", tag, "template", tmpl, err)
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		log.Error("Error executing tag template", "tag", tag, "template", tmpl, err)
+		return ""
+	}
+	return buf.String()
+}